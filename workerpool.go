@@ -3,11 +3,28 @@
 // Jobs can be queued using the Queue() method which also accepts a timeout parameter for timing out queuing and if all workers are too busy.
 //
 // For expanding the queue, Expand() method can be used, which increases the number of workers. If a timeout is provided, these extra workers will stop, if there are not enough jobs to do. It is also possible to explicitly stop extra workers by providing a quit channel.
+//
+// SubmitCtx() and SubmitFuture() are context-aware alternatives to Queue(), for callers (e.g. RPC middleware) that need to cancel queued work and collect its result/error rather than fire-and-forget it.
+//
+// NewAutoScale() makes a pool that grows and shrinks its own worker count between min and max based on queue pressure, instead of requiring callers to call Expand() with a guessed size.
+//
+// A panicking job no longer takes its worker down with it: the panic is recovered and passed to a PanicHandler (SetPanicHandler, or the WithPanicHandler Option), which just logs by default. If a core worker exits for any other reason while the pool is running, a replacement is spawned so the pool never runs fewer than its configured size.
+//
+// NewWithState() makes a pool whose workers each carry their own WorkerState (reset before every job, cleaned up when the worker exits), for reusing scratch buffers across jobs on the same goroutine instead of allocating one per job. QueueState() queues a job that receives it; Queue() keeps working as a stateless adapter around QueueState().
+//
+// Running(), Waiting(), Cap() and Free() give a point-in-time view into the pool, and SetObserver/WithObserver let a caller subscribe to structured Events (JobQueued, JobStarted, JobDone, WorkerSpawned, WorkerTimedOut, QueueFull) for metrics or tracing integrations.
+//
+// StopAndWait() stops the pool from accepting new jobs but drains whatever is already queued before shutting down, and ReleaseTimeout() bounds that shutdown, returning ErrTimeout if workers don't exit in time.
 package workerpool
 
 import (
+	"context"
+	"errors"
+	"log"
 	"runtime"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,38 +34,117 @@ import (
 
 // WorkerPool provides a pool of workers.
 type WorkerPool struct {
-	pool chan chan func()
-	jobs chan func()
+	pool chan chan func(WorkerState)
+	jobs chan func(WorkerState)
+
+	quit      chan struct{}
+	quitOnce  sync.Once
+	draining  chan struct{}
+	drainOnce sync.Once
+	wg        sync.WaitGroup
+	inFlight  sync.WaitGroup // queued-or-running jobs; StopAndWait blocks on this
+
+	scale        *autoScale
+	panicHandler atomic.Pointer[PanicHandler]
+	newState     func() WorkerState
+	newStateMu   sync.Mutex // serializes newState calls across concurrent initWorker callers
+
+	running     int32 // atomic; workers currently executing a job
+	liveWorkers int32 // atomic; workers currently alive, core + extra
+	observer    atomic.Pointer[Observer]
+}
 
-	quit     chan struct{}
-	quitOnce sync.Once
-	wg       sync.WaitGroup
+// poolConfig accumulates the Options passed to New, NewWithState or
+// NewAutoScale. It exists because some of what an Option sets - the job
+// queue's capacity, an auto-scaled pool's check interval and cooldown - has
+// to be known before the pool's channels and goroutines are built, so an
+// Option can't just be applied to an already-constructed *WorkerPool.
+type poolConfig struct {
+	jobQueue      int
+	panicHandler  PanicHandler
+	observer      Observer
+	hasObserver   bool
+	checkInterval time.Duration
+	cooldown      time.Duration
+}
+
+// Option configures a WorkerPool at construction time, via New, NewWithState
+// or NewAutoScale.
+type Option func(*poolConfig)
+
+// WithJobQueue sets the capacity of the pool's job queue. The default is 0,
+// an unbuffered queue, so Queue blocks until a worker is ready or the
+// optional timeout elapses. NewAutoScale defaults this to max*2 instead, but
+// it can still be overridden.
+func WithJobQueue(n int) Option {
+	return func(cfg *poolConfig) {
+		if n > 0 {
+			cfg.jobQueue = n
+		}
+	}
+}
+
+func defaultPoolConfig() poolConfig {
+	return poolConfig{panicHandler: defaultPanicHandler}
 }
 
 // New makes a new *WorkerPool.
-func New(workers int, jobQueue ...int) *WorkerPool {
-	q := 0
-	if len(jobQueue) > 0 && jobQueue[0] > 0 {
-		q = jobQueue[0]
+func New(workers int, opts ...Option) *WorkerPool {
+	cfg := defaultPoolConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return buildPool(workers, nil, cfg)
+}
+
+// NewWithState makes a new *WorkerPool like New, except newState is called
+// once per worker to create that worker's WorkerState, which is reset and
+// handed to every job queued via QueueState that lands on that worker. Jobs
+// queued via Queue keep working unchanged, wrapped into a stateless adapter
+// that ignores the state. newState is always called under an internal lock,
+// never concurrently with itself, even when several workers are started at
+// once (Expand) or self-heal at the same time.
+func NewWithState(workers int, newState func() WorkerState, opts ...Option) *WorkerPool {
+	cfg := defaultPoolConfig()
+	for _, opt := range opts {
+		opt(&cfg)
 	}
+	return buildPool(workers, newState, cfg)
+}
+
+func buildPool(workers int, newState func() WorkerState, cfg poolConfig) *WorkerPool {
 	if workers < 0 {
 		workers = runtime.NumCPU()
 	}
 	pool := WorkerPool{
-		pool: make(chan chan func(), workers),
-		jobs: make(chan func(), q),
-		quit: make(chan struct{}),
-		wg:   sync.WaitGroup{},
+		pool:     make(chan chan func(WorkerState), workers),
+		jobs:     make(chan func(WorkerState), cfg.jobQueue),
+		quit:     make(chan struct{}),
+		draining: make(chan struct{}),
+		wg:       sync.WaitGroup{},
+		newState: newState,
+	}
+	pool.SetPanicHandler(cfg.panicHandler)
+	if cfg.hasObserver {
+		pool.SetObserver(cfg.observer)
 	}
 	for i := 0; i < workers; i++ {
-		initWorker(pool.pool, 0, nil, pool.quit, &pool.wg)
+		initWorker(pool.pool, 0, nil, pool.quit, &pool.wg, &pool.panicHandler, pool.newState, &pool.observer, &pool.running, &pool.liveWorkers, &pool.newStateMu, true)
 	}
 	go pool.dispatch()
 	return &pool
 }
 
-// Queue queues a job to be run by a worker.
+// Queue queues a job to be run by a worker. job is run as a stateless
+// adapter around QueueState, ignoring whatever WorkerState the worker it
+// lands on carries.
 func (pool *WorkerPool) Queue(job func(), timeout ...time.Duration) bool {
+	return pool.QueueState(func(WorkerState) { job() }, timeout...)
+}
+
+// QueueState queues a job to be run by a worker, passing it that worker's
+// WorkerState (nil if the pool was made with New rather than NewWithState).
+func (pool *WorkerPool) QueueState(job func(state WorkerState), timeout ...time.Duration) bool {
 	if pool.stopped() {
 		return false
 	}
@@ -56,22 +152,165 @@ func (pool *WorkerPool) Queue(job func(), timeout ...time.Duration) bool {
 	if len(timeout) > 0 && timeout[0] > 0 {
 		t = time.After(timeout[0])
 	}
+	pool.inFlight.Add(1)
+	wrapped := func(state WorkerState) {
+		defer pool.inFlight.Done()
+		job(state)
+	}
 	select {
-	case pool.jobs <- job:
+	case pool.jobs <- wrapped:
+		emit(&pool.observer, Event{Type: JobQueued})
 	case <-t:
+		pool.inFlight.Done()
+		emit(&pool.observer, Event{Type: QueueFull})
 		return false
 	case <-pool.quit:
+		pool.inFlight.Done()
 		return false
 	}
 	return true
 }
 
-// Stop stops the pool and waits for all workers to return.
+// ErrPoolStopped is returned by SubmitCtx (and surfaced through Future.Wait)
+// when the pool was, or became, stopped before the job could be queued. A job
+// that was already queued when Stop abandons the queue gets no error: it
+// simply never runs.
+var ErrPoolStopped = errors.New("workerpool: pool stopped")
+
+// ErrQueueTimeout is returned by SubmitCtx when the optional timeout elapses
+// before the job could be queued.
+var ErrQueueTimeout = errors.New("workerpool: queue timed out")
+
+// SubmitCtx queues a job to be run by a worker, threading ctx through to it.
+// Unlike Queue, it reports *why* a job could not be submitted: ctx.Err() if
+// ctx is done before the job is queued, ErrPoolStopped if the pool was (or
+// became) stopped, or ErrQueueTimeout if the optional timeout elapses first.
+// Once queued, job is invoked (unless Stop abandons it in the queue) even if
+// ctx is already done by the time it reaches a worker; job is responsible for
+// checking ctx.Err() itself if it cares (SubmitFuture does this for you).
+func (pool *WorkerPool) SubmitCtx(ctx context.Context, job func(ctx context.Context), timeout ...time.Duration) error {
+	if pool.stopped() {
+		return ErrPoolStopped
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var t <-chan time.Time
+	if len(timeout) > 0 && timeout[0] > 0 {
+		t = time.After(timeout[0])
+	}
+	pool.inFlight.Add(1)
+	wrapped := func(WorkerState) {
+		defer pool.inFlight.Done()
+		job(ctx)
+	}
+	select {
+	case pool.jobs <- wrapped:
+		emit(&pool.observer, Event{Type: JobQueued})
+	case <-t:
+		pool.inFlight.Done()
+		emit(&pool.observer, Event{Type: QueueFull})
+		return ErrQueueTimeout
+	case <-pool.quit:
+		pool.inFlight.Done()
+		return ErrPoolStopped
+	case <-ctx.Done():
+		pool.inFlight.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Future is the result of a job submitted via SubmitFuture. The zero value
+// is not usable; obtain one from SubmitFuture.
+type Future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// Wait blocks until the job has finished and returns its result, or the
+// reason it was never queued (see SubmitCtx). If the job was queued and then
+// abandoned by Stop, Wait does not return.
+func (f *Future[T]) Wait() (T, error) {
+	<-f.done
+	return f.val, f.err
+}
+
+// Poll reports whether the job has finished yet. If ok is false, val and err
+// are the zero value and should be ignored.
+func (f *Future[T]) Poll() (val T, err error, ok bool) {
+	select {
+	case <-f.done:
+		return f.val, f.err, true
+	default:
+		var zero T
+		return zero, nil, false
+	}
+}
+
+// SubmitFuture queues a job that returns a typed result and hands back a
+// *Future the caller can Wait or Poll on, instead of blocking for the result
+// or wiring up its own result channel. It is a free function, not a method,
+// since Go methods cannot carry their own type parameters.
+func SubmitFuture[T any](pool *WorkerPool, ctx context.Context, job func(ctx context.Context) (T, error), timeout ...time.Duration) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	err := pool.SubmitCtx(ctx, func(ctx context.Context) {
+		defer close(f.done)
+		if ctx.Err() != nil {
+			f.err = ctx.Err()
+			return
+		}
+		f.val, f.err = job(ctx)
+	}, timeout...)
+	if err != nil {
+		f.err = err
+		close(f.done)
+	}
+	return f
+}
+
+// Stop stops the pool and waits for all workers to return. Any job still
+// sitting in the queue is abandoned.
 func (pool *WorkerPool) Stop() {
 	pool.quitOnce.Do(func() { close(pool.quit) })
 	pool.wg.Wait()
 }
 
+// ErrTimeout is returned by ReleaseTimeout when the pool's workers have not
+// all exited within the given duration.
+var ErrTimeout = errors.New("workerpool: release timed out")
+
+// StopAndWait stops the pool from accepting new jobs - Queue, QueueState and
+// SubmitCtx all fail immediately, as if the pool were already stopped - but,
+// unlike Stop, lets workers drain whatever is already queued before the
+// pool actually shuts down. It blocks on inFlight, a WaitGroup covering
+// every queued-or-running job, rather than polling the queue and running
+// count.
+func (pool *WorkerPool) StopAndWait() {
+	pool.drainOnce.Do(func() { close(pool.draining) })
+	pool.inFlight.Wait()
+	pool.Stop()
+}
+
+// ReleaseTimeout initiates a StopAndWait and returns ErrTimeout if the
+// pool's workers have not all exited within d, nil otherwise. It blocks on
+// an allDone channel that's closed the moment StopAndWait returns, so it
+// never busy-waits either.
+func (pool *WorkerPool) ReleaseTimeout(d time.Duration) error {
+	allDone := make(chan struct{})
+	go func() {
+		pool.StopAndWait()
+		close(allDone)
+	}()
+	select {
+	case <-allDone:
+		return nil
+	case <-time.After(d):
+		return ErrTimeout
+	}
+}
+
 // Expand is for putting more 'Worker's into work. If there is'nt any job to do,
 // and a timeout is set, they will simply get timed-out.
 // Default behaviour is they will timeout in a sliding manner.
@@ -98,13 +337,152 @@ func (pool *WorkerPool) Expand(n int, timeout time.Duration, quit <-chan struct{
 		return false
 	}
 	for i := 0; i < n; i++ {
-		initWorker(pool.pool, timeout, quit, pool.quit, &pool.wg)
+		initWorker(pool.pool, timeout, quit, pool.quit, &pool.wg, &pool.panicHandler, pool.newState, &pool.observer, &pool.running, &pool.liveWorkers, &pool.newStateMu, false)
 	}
 	return true
 }
 
+const (
+	// defaultAutoScaleCheckInterval is how often an auto-scaled pool
+	// reassesses queue pressure.
+	defaultAutoScaleCheckInterval = 50 * time.Millisecond
+	// defaultAutoScaleCooldown is how long the queue must stay empty
+	// before an auto-scaled pool halves its surplus workers.
+	defaultAutoScaleCooldown = 2 * time.Second
+	// autoScalePressureThreshold is the fraction of the pool's current
+	// workers that must be busy, while jobs are still waiting, before
+	// autoScaleLoop grows it. It's measured against the live worker count,
+	// not a queue-depth target (scale.max or the job queue's channel
+	// capacity): any fixed backlog-size target is self-cancelling, since
+	// adding a worker to address backlog shrinks that same backlog,
+	// stalling growth before it ever catches up with real demand.
+	autoScalePressureThreshold = 0.75
+)
+
+// autoScale holds the elastic-sizing state for a pool created with
+// NewAutoScale. A pool made with New has a nil scale and never grows or
+// shrinks on its own.
+type autoScale struct {
+	min, max      int32
+	current       int32 // atomic; workers alive right now
+	checkInterval time.Duration
+	cooldown      time.Duration
+}
+
+// WithCheckInterval overrides how often NewAutoScale reassesses queue
+// pressure. The default is 50ms. It has no effect on New or NewWithState.
+func WithCheckInterval(d time.Duration) Option {
+	return func(cfg *poolConfig) {
+		if d > 0 {
+			cfg.checkInterval = d
+		}
+	}
+}
+
+// WithCooldown overrides how long an auto-scaled pool's queue must stay
+// empty before its surplus workers (the ones above min) get halved. The
+// default is 2s. It has no effect on New or NewWithState.
+func WithCooldown(d time.Duration) Option {
+	return func(cfg *poolConfig) {
+		if d > 0 {
+			cfg.cooldown = d
+		}
+	}
+}
+
+// NewAutoScale makes a new *WorkerPool that starts at min workers and grows
+// towards max as the job queue fills up, shrinking surplus workers back
+// towards min once the queue has sat empty for a cooldown period. It saves
+// callers from calling Expand manually and guessing at sizes.
+func NewAutoScale(min, max int, opts ...Option) *WorkerPool {
+	if min < 0 {
+		min = 0
+	}
+	if max < min {
+		max = min
+	}
+	cfg := defaultPoolConfig()
+	cfg.jobQueue = max * 2
+	cfg.checkInterval = defaultAutoScaleCheckInterval
+	cfg.cooldown = defaultAutoScaleCooldown
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	pool := buildPool(min, nil, cfg)
+	pool.scale = &autoScale{
+		min:           int32(min),
+		max:           int32(max),
+		current:       int32(min),
+		checkInterval: cfg.checkInterval,
+		cooldown:      cfg.cooldown,
+	}
+	go pool.autoScaleLoop()
+	return pool
+}
+
+// autoScaleLoop grows the pool towards scale.max while the job queue is
+// under pressure, and shrinks surplus workers (those beyond scale.min) back
+// down, halving them once the queue has been empty for scale.cooldown. Extra
+// workers are started with their own quit channel (reusing initWorker's
+// timeout/quit machinery, as Expand does) so shrinking is just closing that
+// channel, letting the worker retire after its current job, if any.
+func (pool *WorkerPool) autoScaleLoop() {
+	s := pool.scale
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	var (
+		extras    []chan struct{}
+		idle      bool
+		idleSince time.Time
+	)
+
+	for {
+		select {
+		case <-pool.quit:
+			return
+		case <-ticker.C:
+		}
+
+		pending := len(pool.jobs)
+		current := atomic.LoadInt32(&s.current)
+		busy := pool.Running()
+
+		switch {
+		case pending > 0 && current < s.max && busy >= int32(float64(current)*autoScalePressureThreshold):
+			idle = false
+			q := make(chan struct{})
+			if initWorker(pool.pool, 0, q, pool.quit, &pool.wg, &pool.panicHandler, pool.newState, &pool.observer, &pool.running, &pool.liveWorkers, &pool.newStateMu, false) != nil {
+				extras = append(extras, q)
+				atomic.AddInt32(&s.current, 1)
+			}
+
+		case pending == 0:
+			if !idle {
+				idle = true
+				idleSince = time.Now()
+				continue
+			}
+			if len(extras) == 0 || time.Since(idleSince) < s.cooldown {
+				continue
+			}
+			surplus := (len(extras) + 1) / 2 // halve, rounding up
+			for i := 0; i < surplus && len(extras) > 0 && atomic.LoadInt32(&s.current) > s.min; i++ {
+				last := len(extras) - 1
+				close(extras[last])
+				extras = extras[:last]
+				atomic.AddInt32(&s.current, -1)
+			}
+			idleSince = time.Now()
+
+		default:
+			idle = false
+		}
+	}
+}
+
 func (pool *WorkerPool) stopped() bool {
-	return stopped(pool.quit)
+	return stopped(pool.quit) || stopped(pool.draining)
 }
 
 func (pool *WorkerPool) dispatch() {
@@ -122,16 +500,206 @@ func (pool *WorkerPool) dispatch() {
 
 //-----------------------------------------------------------------------------
 
+// PanicHandler is invoked, with the recovered value and the stack trace
+// captured at the point of panic, whenever a job panics instead of
+// returning normally.
+type PanicHandler func(recovered interface{}, stack []byte)
+
+// defaultPanicHandler logs the panic and lets the worker carry on.
+func defaultPanicHandler(recovered interface{}, stack []byte) {
+	log.Printf("workerpool: recovered from panic in job: %v\n%s", recovered, stack)
+}
+
+// SetPanicHandler installs h to be called whenever a job panics, replacing
+// the default logging handler. It is safe to call at any time, including
+// while the pool is running.
+func (pool *WorkerPool) SetPanicHandler(h PanicHandler) {
+	pool.panicHandler.Store(&h)
+}
+
+// WithPanicHandler is the Option form of SetPanicHandler, for installing h
+// before the pool's workers start rather than racing SetPanicHandler against
+// them. Works with New, NewWithState and NewAutoScale alike.
+func WithPanicHandler(h PanicHandler) Option {
+	return func(cfg *poolConfig) {
+		if h != nil {
+			cfg.panicHandler = h
+		}
+	}
+}
+
+// Running returns the number of workers currently executing a job.
+func (pool *WorkerPool) Running() int32 {
+	return atomic.LoadInt32(&pool.running)
+}
+
+// Waiting returns the number of workers currently registered and idle,
+// waiting for a job.
+func (pool *WorkerPool) Waiting() int32 {
+	return int32(len(pool.pool))
+}
+
+// Cap returns the number of workers currently alive in the pool. For a plain
+// New pool this is constant, but it tracks growth and shrinkage from Expand
+// or NewAutoScale, unlike the pool's original channel capacity.
+func (pool *WorkerPool) Cap() int {
+	return int(atomic.LoadInt32(&pool.liveWorkers))
+}
+
+// Free returns how many currently-alive workers are not busy running a job.
+func (pool *WorkerPool) Free() int {
+	return pool.Cap() - int(pool.Running())
+}
+
+// EventType identifies the kind of occurrence an Event describes.
+type EventType int
+
+// The event types emitted to an Observer.
+const (
+	JobQueued EventType = iota
+	JobStarted
+	JobDone
+	WorkerSpawned
+	WorkerTimedOut
+	QueueFull
+)
+
+func (t EventType) String() string {
+	switch t {
+	case JobQueued:
+		return "JobQueued"
+	case JobStarted:
+		return "JobStarted"
+	case JobDone:
+		return "JobDone"
+	case WorkerSpawned:
+		return "WorkerSpawned"
+	case WorkerTimedOut:
+		return "WorkerTimedOut"
+	case QueueFull:
+		return "QueueFull"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is a structured notification emitted to a pool's Observer. Panic is
+// set on a JobDone event if the job panicked and was recovered; it is nil
+// for every other event.
+type Event struct {
+	Type  EventType
+	Panic interface{}
+}
+
+// Observer receives Events from a pool, for metrics/tracing integrations
+// (e.g. Prometheus or OpenTelemetry) that want visibility into what would
+// otherwise be a black box.
+type Observer func(Event)
+
+// SetObserver installs obs to receive Events from the pool, replacing any
+// previously set Observer. Passing nil disables event emission. It is safe
+// to call at any time, including while the pool is running.
+func (pool *WorkerPool) SetObserver(obs Observer) {
+	if obs == nil {
+		pool.observer.Store(nil)
+		return
+	}
+	pool.observer.Store(&obs)
+}
+
+// WithObserver is the Option form of SetObserver, for installing obs before
+// the pool's workers start rather than racing SetObserver against them.
+// Works with New, NewWithState and NewAutoScale alike.
+func WithObserver(obs Observer) Option {
+	return func(cfg *poolConfig) {
+		cfg.observer = obs
+		cfg.hasObserver = true
+	}
+}
+
+func emit(observer *atomic.Pointer[Observer], evt Event) {
+	if observer == nil {
+		return
+	}
+	if obs := observer.Load(); obs != nil {
+		(*obs)(evt)
+	}
+}
+
+// WorkerState is optional per-worker state, created once per worker by
+// NewWithState and threaded into every job that lands on that worker. It
+// lets jobs reuse scratch resources (e.g. a bytes.Buffer or gzip.Writer)
+// across calls on the same goroutine instead of allocating one per job.
+type WorkerState interface {
+	// Reset is called before each job runs on the worker that owns this
+	// state.
+	Reset()
+	// Cleanup is called once, when the worker that owns this state exits.
+	Cleanup()
+}
+
 type worker struct {
-	pool     chan chan func()
-	poolQuit <-chan struct{}
-	todo     chan func()
-	timeout  time.Duration
-	quit     <-chan struct{}
+	pool         chan chan func(WorkerState)
+	poolQuit     <-chan struct{}
+	todo         chan func(WorkerState)
+	timeout      time.Duration
+	quit         <-chan struct{}
+	panicHandler *atomic.Pointer[PanicHandler]
+	newState     func() WorkerState
+	state        WorkerState
+	observer     *atomic.Pointer[Observer]
+	running      *int32
+	liveWorkers  *int32
+	newStateMu   *sync.Mutex
+	// core marks one of the pool's originally-configured workers, as
+	// opposed to a timeout-bound extra from Expand or NewAutoScale. Only
+	// core workers get replaced by self-healing, since the others are
+	// meant to retire once demand drops.
+	core bool
+}
+
+// run executes job against state, recovering any panic so the worker
+// goroutine survives to register for its next job.
+func (w *worker) run(job func(WorkerState), state WorkerState) {
+	if w.running != nil {
+		atomic.AddInt32(w.running, 1)
+		defer atomic.AddInt32(w.running, -1)
+	}
+	emit(w.observer, Event{Type: JobStarted})
+	var recovered interface{}
+	defer func() {
+		emit(w.observer, Event{Type: JobDone, Panic: recovered})
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			recovered = r
+			handler := defaultPanicHandler
+			if w.panicHandler != nil {
+				if h := w.panicHandler.Load(); h != nil {
+					handler = *h
+				}
+			}
+			handler(r, debug.Stack())
+		}
+	}()
+	job(state)
 }
 
 func (w *worker) begin(wg *sync.WaitGroup) {
 	defer wg.Done()
+	// Self-healing: if this is one of the pool's core workers and it's
+	// exiting while the pool is still running, spawn a replacement so
+	// the "N workers alive until Stop" invariant holds regardless of why
+	// this particular goroutine returned.
+	defer func() {
+		if w.core && !stopped(w.poolQuit) {
+			initWorker(w.pool, w.timeout, w.quit, w.poolQuit, wg, w.panicHandler, w.newState, w.observer, w.running, w.liveWorkers, w.newStateMu, true)
+		}
+	}()
+	defer atomic.AddInt32(w.liveWorkers, -1)
+	if w.state != nil {
+		defer w.state.Cleanup()
+	}
 	var timeout <-chan time.Time
 
 	for {
@@ -155,6 +723,7 @@ func (w *worker) begin(wg *sync.WaitGroup) {
 		case <-timeout:
 			//failed to register; means WorkerPool is full == there are
 			//enough workers with not enough work!
+			emit(w.observer, Event{Type: WorkerTimedOut})
 			return
 		case <-w.quit:
 			return
@@ -169,15 +738,19 @@ func (w *worker) begin(wg *sync.WaitGroup) {
 			}
 
 			if job != nil {
-				job()
+				if w.state != nil {
+					w.state.Reset()
+				}
+				w.run(job, w.state)
 			}
-			// we do not check for timeout or quit here because a registered worker
+			// we do not check for timeout here because a registered worker
 			// is meant to do his job
 			// (& implementing unregistering would be complicated, inefficiet & unnecessary)
-			// unless the whole pool is quit (a prototype implemented using a priority queue
-			// - a heap - but it was just more complicated and did not add much; should
-			// investigate it more deeply; but this just works fine; after the burst,
-			// the expanded workers would just do their last job, eventually).
+			// unless the whole pool is quit or this worker's own quit fires -
+			// otherwise a registered-but-idle extra worker would never notice
+			// it had been retired until it was handed one more job first.
+		case <-w.quit:
+			return
 		case <-w.poolQuit:
 			return
 		}
@@ -185,24 +758,50 @@ func (w *worker) begin(wg *sync.WaitGroup) {
 }
 
 func initWorker(
-	pool chan chan func(),
+	pool chan chan func(WorkerState),
 	timeout time.Duration,
 	quit <-chan struct{},
 	poolQuit <-chan struct{},
-	wg *sync.WaitGroup) *worker {
+	wg *sync.WaitGroup,
+	panicHandler *atomic.Pointer[PanicHandler],
+	newState func() WorkerState,
+	observer *atomic.Pointer[Observer],
+	running *int32,
+	liveWorkers *int32,
+	newStateMu *sync.Mutex,
+	core bool) *worker {
 	if stopped(poolQuit) {
 		return nil
 	}
 	w := &worker{
-		pool:     pool,
-		todo:     make(chan func()),
-		timeout:  timeout,
-		quit:     quit,
-		poolQuit: poolQuit,
+		pool:         pool,
+		todo:         make(chan func(WorkerState)),
+		timeout:      timeout,
+		quit:         quit,
+		poolQuit:     poolQuit,
+		panicHandler: panicHandler,
+		newState:     newState,
+		observer:     observer,
+		running:      running,
+		liveWorkers:  liveWorkers,
+		newStateMu:   newStateMu,
+		core:         core,
+	}
+	// newState is called here, synchronously and under newStateMu, rather
+	// than from w.begin on the worker's own goroutine: initWorker can be
+	// called from several goroutines at once (e.g. two core workers
+	// self-healing at the same time), and newState has no documented
+	// thread-safety contract of its own.
+	if newState != nil {
+		newStateMu.Lock()
+		w.state = newState()
+		newStateMu.Unlock()
 	}
 
 	wg.Add(1)
+	atomic.AddInt32(liveWorkers, 1)
 	go w.begin(wg)
+	emit(observer, Event{Type: WorkerSpawned})
 
 	return w
 }
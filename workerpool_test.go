@@ -0,0 +1,314 @@
+package workerpool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestSubmitFutureContextTimeout guards against a regression where
+// SubmitCtx's skip-on-done-ctx logic ran inside the job wrapper SubmitFuture
+// passes in, so a job skipped because its ctx was already done never ran
+// SubmitFuture's inner closure, leaving Future.done unclosed forever.
+func TestSubmitFutureContextTimeout(t *testing.T) {
+	pool := New(1)
+	defer pool.Stop()
+
+	// Occupy the single worker long enough for ctx below to expire while
+	// the SubmitFuture job is still sitting in the queue.
+	pool.Queue(func() { time.Sleep(300 * time.Millisecond) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	f := SubmitFuture(pool, ctx, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+
+	done := make(chan struct{})
+	var val int
+	var err error
+	go func() {
+		val, err = f.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Future.Wait() never returned after its ctx timed out")
+	}
+
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Wait() error = %v, want context.DeadlineExceeded", err)
+	}
+	if val != 0 {
+		t.Fatalf("Wait() val = %d, want zero value", val)
+	}
+}
+
+// TestSubmitFutureHappyPath checks that a job queued via SubmitFuture runs
+// and that Wait/Poll report its result once it has.
+func TestSubmitFutureHappyPath(t *testing.T) {
+	pool := New(1)
+	defer pool.Stop()
+
+	f := SubmitFuture(pool, context.Background(), func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+
+	val, err := f.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v, want nil", err)
+	}
+	if val != 42 {
+		t.Fatalf("Wait() val = %d, want 42", val)
+	}
+
+	val, err, ok := f.Poll()
+	if !ok {
+		t.Fatal("Poll() ok = false after Wait() already returned")
+	}
+	if err != nil || val != 42 {
+		t.Fatalf("Poll() = (%d, %v), want (42, nil)", val, err)
+	}
+}
+
+// TestSubmitCtxPoolStopped checks that SubmitCtx reports ErrPoolStopped,
+// rather than blocking or silently dropping the job, once the pool has
+// stopped.
+func TestSubmitCtxPoolStopped(t *testing.T) {
+	pool := New(1)
+	pool.Stop()
+
+	err := pool.SubmitCtx(context.Background(), func(ctx context.Context) {})
+	if err != ErrPoolStopped {
+		t.Fatalf("SubmitCtx() error = %v, want ErrPoolStopped", err)
+	}
+}
+
+// TestStopAndWaitDrainsQueue checks that StopAndWait lets every job already
+// queued run to completion, and that Queue stops accepting new jobs as soon
+// as StopAndWait has been called.
+func TestStopAndWaitDrainsQueue(t *testing.T) {
+	pool := New(1)
+
+	var ran [5]bool
+	for i := range ran {
+		i := i
+		pool.Queue(func() { ran[i] = true })
+	}
+
+	done := make(chan struct{})
+	go func() {
+		pool.StopAndWait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("StopAndWait() never returned")
+	}
+
+	for i, r := range ran {
+		if !r {
+			t.Fatalf("job %d never ran before StopAndWait() returned", i)
+		}
+	}
+
+	if pool.Queue(func() {}) {
+		t.Fatal("Queue() = true after StopAndWait(), want false")
+	}
+}
+
+// TestReleaseTimeoutReportsErrTimeout checks that ReleaseTimeout returns
+// ErrTimeout, rather than blocking indefinitely, when a queued job outlives
+// the given duration.
+func TestReleaseTimeoutReportsErrTimeout(t *testing.T) {
+	pool := New(1)
+	defer pool.Stop()
+
+	release := make(chan struct{})
+	pool.Queue(func() { <-release })
+
+	err := pool.ReleaseTimeout(50 * time.Millisecond)
+	close(release)
+
+	if err != ErrTimeout {
+		t.Fatalf("ReleaseTimeout() error = %v, want ErrTimeout", err)
+	}
+}
+
+// countingState is a WorkerState that counts how many times Reset and
+// Cleanup were called on it.
+type countingState struct {
+	resets   int
+	cleanups int
+}
+
+func (s *countingState) Reset()   { s.resets++ }
+func (s *countingState) Cleanup() { s.cleanups++ }
+
+// TestNewWithStateResetAndCleanup checks that a NewWithState pool resets its
+// worker's state before every job and cleans it up once, when the worker
+// exits on Stop.
+func TestNewWithStateResetAndCleanup(t *testing.T) {
+	state := &countingState{}
+	pool := NewWithState(1, func() WorkerState { return state })
+
+	var seenResets []int
+	for i := 0; i < 3; i++ {
+		done := make(chan struct{})
+		pool.QueueState(func(s WorkerState) {
+			seenResets = append(seenResets, s.(*countingState).resets)
+			close(done)
+		})
+		<-done
+	}
+	pool.Stop()
+
+	if len(seenResets) != 3 {
+		t.Fatalf("ran %d jobs, want 3", len(seenResets))
+	}
+	for i, r := range seenResets {
+		if r != i+1 {
+			t.Fatalf("job %d saw resets = %d, want %d", i, r, i+1)
+		}
+	}
+	if state.cleanups != 1 {
+		t.Fatalf("cleanups = %d, want 1 after Stop", state.cleanups)
+	}
+}
+
+// TestPanicRecoverySurvivesAndReports checks that a panicking job doesn't
+// take its worker down with it (the pool keeps servicing jobs afterwards)
+// and that the configured PanicHandler is invoked with the recovered value.
+func TestPanicRecoverySurvivesAndReports(t *testing.T) {
+	recovered := make(chan interface{}, 1)
+	pool := New(1, WithPanicHandler(func(r interface{}, stack []byte) {
+		recovered <- r
+	}))
+	defer pool.Stop()
+
+	pool.Queue(func() { panic("boom") })
+
+	select {
+	case r := <-recovered:
+		if r != "boom" {
+			t.Fatalf("PanicHandler recovered = %v, want \"boom\"", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("PanicHandler was never called")
+	}
+
+	done := make(chan struct{})
+	if !pool.Queue(func() { close(done) }) {
+		t.Fatal("Queue() = false after a panic, want the pool to still accept jobs")
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job queued after a panic never ran - worker did not survive")
+	}
+}
+
+// TestObserverReceivesJobEvents checks that a pool's Observer is sent
+// JobStarted and JobDone for a job that panics, and that JobDone carries the
+// recovered value.
+func TestObserverReceivesJobEvents(t *testing.T) {
+	events := make(chan Event, 8)
+	pool := New(1, WithObserver(func(e Event) { events <- e }))
+	defer pool.Stop()
+
+	pool.Queue(func() { panic("boom") })
+
+	var started, done bool
+	var donePanic interface{}
+	deadline := time.After(2 * time.Second)
+	for !started || !done {
+		select {
+		case e := <-events:
+			switch e.Type {
+			case JobStarted:
+				started = true
+			case JobDone:
+				done = true
+				donePanic = e.Panic
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for JobStarted/JobDone (started=%v done=%v)", started, done)
+		}
+	}
+	if donePanic != "boom" {
+		t.Fatalf("JobDone.Panic = %v, want \"boom\"", donePanic)
+	}
+}
+
+// TestAutoScaleGrowsAndShrinks checks that NewAutoScale actually grows past
+// min while a burst of blocking jobs is queued, and shrinks its surplus
+// workers back down to min once the burst drains and a cooldown passes.
+func TestAutoScaleGrowsAndShrinks(t *testing.T) {
+	pool := NewAutoScale(1, 10, WithCooldown(100*time.Millisecond))
+	defer pool.Stop()
+
+	release := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		pool.Queue(func() { <-release })
+	}
+
+	growDeadline := time.Now().Add(2 * time.Second)
+	for pool.Cap() < 5 && time.Now().Before(growDeadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if cap := pool.Cap(); cap < 5 {
+		close(release)
+		t.Fatalf("Cap() = %d, want >= 5 after queuing 8 blocking jobs", cap)
+	}
+
+	close(release)
+
+	shrinkDeadline := time.Now().Add(2 * time.Second)
+	for pool.Cap() > 1 && time.Now().Before(shrinkDeadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if cap := pool.Cap(); cap > 1 {
+		t.Fatalf("Cap() = %d, want == 1 (min) once the queue has been idle past the cooldown", cap)
+	}
+}
+
+// TestCapFreeTrackGrowth guards against a regression where Cap() returned
+// the pool's construction-time channel capacity rather than its live worker
+// count, so Free() (Cap() - Running()) went negative as soon as an
+// auto-scaled pool grew past its initial size.
+func TestCapFreeTrackGrowth(t *testing.T) {
+	pool := NewAutoScale(1, 10)
+	defer pool.Stop()
+
+	release := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		pool.Queue(func() { <-release })
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.Running() < 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	running := pool.Running()
+	if running < 5 {
+		close(release)
+		t.Fatalf("Running() = %d, want >= 5 before deadline", running)
+	}
+
+	capacity := pool.Cap()
+	free := pool.Free()
+	close(release)
+
+	if capacity < int(running) {
+		t.Fatalf("Cap() = %d, want >= Running() = %d", capacity, running)
+	}
+	if free < 0 {
+		t.Fatalf("Free() = %d, want >= 0", free)
+	}
+}